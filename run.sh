@@ -30,20 +30,50 @@ flink='bash'
 flink-jobmanager='bash'
 "
 
+# Only match the image name itself (e.g. provectuslabs/kafka-ui should not match kafka)
+image_prefix="[\"']?image[\"']?:[[:space:]]*[\"']?([^/[:space:]]+/)*"
+image_suffix="([:@\"'[:space:]]|$)"
+detect_patterns="
+cassandra='jdbc:cassandra|spring\.cassandra|^[[:space:]]*cassandra:|://[^/[:space:]]+:9042|gocql/gocql|${image_prefix}cassandra${image_suffix}'
+clickhouse='jdbc:clickhouse|clickhouse://|clickhouse-go|${image_prefix}clickhouse(-server)?${image_suffix}'
+elasticsearch='spring\.elasticsearch|^[[:space:]]*elasticsearch:|://[^/[:space:]]+:9200|go-elasticsearch|olivere/elastic|${image_prefix}elasticsearch${image_suffix}'
+kafka='bootstrap[._-]servers|kafka://|segmentio/kafka-go|confluent-kafka-go|IBM/sarama|Shopify/sarama|${image_prefix}((cp-)?kafka|confluent-local)${image_suffix}'
+mariadb='jdbc:mariadb|mariadb://|${image_prefix}mariadb${image_suffix}'
+minio='minio-go|${image_prefix}minio${image_suffix}'
+mongodb='mongodb(\+srv)?://|mongo-driver|${image_prefix}mongo(db)?${image_suffix}'
+mysql='jdbc:mysql|mysql://|go-sql-driver/mysql|${image_prefix}mysql(-server)?${image_suffix}'
+neo4j='bolt://|neo4j://|neo4j-go-driver|${image_prefix}neo4j${image_suffix}'
+postgres='jdbc:postgresql|postgres(ql)?://|lib/pq|jackc/pgx|${image_prefix}(postgres|postgis)${image_suffix}'
+rabbitmq='amqp://|amqp091-go|streadway/amqp|${image_prefix}rabbitmq${image_suffix}'
+trino='jdbc:trino|trino://|trino-go-client|${image_prefix}trino${image_suffix}'
+zookeeper='zookeeper://|${image_prefix}(cp-)?zookeeper${image_suffix}'
+"
+
 usage() {
   echo "Usage: $(basename "$0") [options...] [services...]"
   echo
   echo "    <services>                Name of services to run"
   echo "    -c, connect [service]     Connect to service"
+  echo "        --record <file>       Record connect session to file (asciinema format, also --record=<file>)"
   echo "    -d, down [services...]    Shutdown services (if empty, shutdown all services)"
+  echo "    detect [directory]        Detect services used by application config/code (default: current directory)"
+  echo "    export <dir> [services...]"
+  echo "                              Export a portable copy of insta-infra with pinned image digests"
   echo "    -h, --help, help          Show help"
   echo "    -l, list                  List supported services"
+  echo "    -p, prewarm [services...] Pull images and create containers without starting them"
   echo "    -r, remove [services...]  Remove persisted data (if empty, remove all services persisted data)"
   echo
   echo "Examples:"
   echo "    $(basename "$0") -l"
   echo "    $(basename "$0") postgres           Spin up Postgres"
   echo "    $(basename "$0") -c postgres        Connect to Postgres"
+  echo "    $(basename "$0") -c postgres --record session.cast"
+  echo "                                      Connect to Postgres and record the session"
+  echo "    $(basename "$0") -p postgres        Pre-pull and create Postgres for a faster start"
+  echo "    $(basename "$0") detect             Suggest services to run for the current project"
+  echo "    $(basename "$0") export myenv postgres"
+  echo "                                      Export a portable environment for Postgres to myenv"
   echo "    $(basename "$0") -d                 Bring Postgres down"
   echo "    $(basename "$0") -r postgres        Remove Postgres persisted data"
   exit 0
@@ -56,18 +86,67 @@ connect_to_service() {
     exit 1
   fi
 
-  echo -e "${GREEN}Connecting to $1...${NC}"
-  base_command=$(echo "$connection_commands" | grep "^$1")
+  service="$1"
+  shift
+  record_file=""
+  while [ $# -gt 0 ]
+  do
+    case $1 in
+      "--record")
+        record_file="$2"
+        if [ -z "$record_file" ]
+        then
+          echo -e "${RED}Error: No file passed to --record${NC}"
+          exit 1
+        fi
+        shift 2
+        ;;
+      "--record="*)
+        record_file="${1#--record=}"
+        if [ -z "$record_file" ]
+        then
+          echo -e "${RED}Error: No file passed to --record${NC}"
+          exit 1
+        fi
+        shift
+        ;;
+      *)
+        echo -e "${RED}Error: Unknown argument for connect: $1${NC}"
+        exit 1
+        ;;
+    esac
+  done
+
+  if [ -n "$record_file" ]
+  then
+    if ! command -v asciinema &>/dev/null
+    then
+      echo -e "${RED}Error: asciinema could not be found, required for --record${NC}"
+      exit 1
+    fi
+  fi
+
+  echo -e "${GREEN}Connecting to $service...${NC}"
+  base_command=$(echo "$connection_commands" | grep "^$service")
   IFS=$'\t' read -r container_name connection_command \
     < <(sed -nr "s/(.*)='(.*)'/\1\t\2/p" <<< "$base_command")
 
   if [ -z "$connection_command" ]
   then
-    echo -e "${RED}Error: Failed to find connection command for $1${NC}"
+    echo -e "${RED}Error: Failed to find connection command for $service${NC}"
     exit 1
   fi
 
-  docker exec -it "$container_name" bash -c "$connection_command"
+  if [ -n "$record_file" ]
+  then
+    # asciinema runs the command via sh, so run it in bash for the bash-quoted connection command
+    record_command="docker exec -it $container_name bash -c $(printf '%q' "$connection_command")"
+    echo -e "${GREEN}Recording session to $record_file...${NC}"
+    asciinema rec --title "insta-infra: $service" \
+      --command "bash -c '${record_command//\'/\'\\\'\'}'" "$record_file"
+  else
+    docker exec -it "$container_name" bash -c "$connection_command"
+  fi
 }
 
 shutdown_service() {
@@ -108,6 +187,147 @@ startup_services() {
   sleep 2
 }
 
+prewarm_services() {
+  if [ -z "$1" ]
+  then
+    echo -e "${RED}Error: No service name passed as argument${NC}"
+    exit 1
+  fi
+
+  echo -e "${GREEN}Pulling images for services: $*...${NC}"
+  docker-compose -f "$SCRIPT_DIR/docker-compose.yaml" pull --include-deps "$@"
+  if [ $? != 0 ]; then
+    echo -e "${RED}Error: Failed to pull images${NC}"
+    exit 1
+  fi
+  echo -e "${GREEN}Creating containers for services: $*...${NC}"
+  docker-compose -f "$SCRIPT_DIR/docker-compose.yaml" up --no-start "$@"
+  if [ $? != 0 ]; then
+    echo -e "${RED}Error: Failed to create containers${NC}"
+    exit 1
+  fi
+}
+
+detect_services() {
+  search_dir="${1:-.}"
+  if [ ! -d "$search_dir" ]; then
+    echo -e "${RED}Error: Directory $search_dir does not exist${NC}"
+    exit 1
+  fi
+
+  echo -e "${GREEN}Scanning $search_dir for services...${NC}"
+  detected_services=()
+  detect_result=("${YELLOW}Service,Found In")
+  while IFS=$'\t' read -r service pattern; do
+    matched_files=$(grep -rIlE "$pattern" "$search_dir" \
+      --exclude-dir=.git --exclude-dir=node_modules --exclude-dir=vendor --exclude-dir=target --exclude-dir=build \
+      --include="application*.yaml" --include="application*.yml" --include="application*.properties" \
+      --include=".env*" --include="*.go" --include="go.mod" \
+      --include="docker-compose*.yaml" --include="docker-compose*.yml" --include="compose*.yaml" --include="compose*.yml" \
+      2>/dev/null | sort)
+    if [ -n "$matched_files" ]; then
+      detected_services+=("$service")
+      detect_result+=("${RED}$service,${LIGHT_BLUE}$(echo "$matched_files" | head -3 | paste -sd ';' -)")
+    fi
+  done < <(sed -nr "s/(.*)='(.*)'/\1\t\2/p" <<< "$detect_patterns")
+
+  if [ ${#detected_services[@]} -eq 0 ]; then
+    echo -e "${YELLOW}No services detected${NC}"
+    exit 0
+  fi
+
+  for value in "${detect_result[@]}"; do
+      echo -e "$value"
+  done | column -t -s ','
+  echo
+  echo -e "${GREEN}To start detected services, run:${NC}"
+  echo "$(basename "$0") ${detected_services[*]}"
+}
+
+export_portable() {
+  export_dir="$1"
+  if [ -z "$export_dir" ]; then
+    echo -e "${RED}Error: No export directory passed as argument${NC}"
+    exit 1
+  fi
+  if [ -e "$export_dir" ] && [ -n "$(ls -A "$export_dir")" ]; then
+    echo -e "${RED}Error: Export directory $export_dir is not empty${NC}"
+    exit 1
+  fi
+  shift
+
+  case $(docker-compose version --short 2>/dev/null) in
+    1.*|v1.*)
+      echo -e "${RED}Error: export requires Docker Compose v2 or later${NC}"
+      exit 1
+      ;;
+  esac
+
+  images=$(docker-compose -f "$SCRIPT_DIR/docker-compose.yaml" config --images "$@")
+  if [ $? != 0 ] || [ -z "$images" ]; then
+    echo -e "${RED}Error: Failed to resolve images for services${NC}"
+    exit 1
+  fi
+
+  echo -e "${GREEN}Exporting portable environment to $export_dir...${NC}"
+  mkdir -p "$export_dir"
+  if [ $? != 0 ]; then
+    echo -e "${RED}Error: Failed to create export directory $export_dir${NC}"
+    exit 1
+  fi
+  tar -C "$SCRIPT_DIR" --exclude="persist" -cf - run.sh docker-compose.yaml README.md data | tar -C "$export_dir" -xf -
+  copy_status=("${PIPESTATUS[@]}")
+  if [ "${copy_status[0]}" != 0 ] || [ "${copy_status[1]}" != 0 ]; then
+    echo -e "${RED}Error: Failed to copy insta-infra files to $export_dir${NC}"
+    exit 1
+  fi
+
+  # Pin image versions set in this environment, otherwise docker-compose.yaml falls back to its defaults on another
+  # machine and no longer matches images.txt
+  pinned_versions=""
+  for version_var in $(grep -oE '\$\{[A-Z0-9_]+_VERSION:-' "$SCRIPT_DIR/docker-compose.yaml" | sed -nr 's/\$\{(.*):-/\1/p' | sort -u); do
+    version="${!version_var}"
+    if [ -z "$version" ] && [ -f "$SCRIPT_DIR/.env" ]; then
+      version=$(sed -nr "s/^${version_var}=(.*)$/\1/p" "$SCRIPT_DIR/.env" | tail -1)
+    fi
+    if [ -n "$version" ]; then
+      pinned_versions+="${version_var}=${version}"$'\n'
+    fi
+  done
+  if [ -n "$pinned_versions" ]; then
+    printf '%s' "$pinned_versions" > "$export_dir/.env"
+    if [ $? != 0 ]; then
+      echo -e "${RED}Error: Failed to write pinned versions to $export_dir/.env${NC}"
+      exit 1
+    fi
+  fi
+
+  : > "$export_dir/images.txt"
+  for image in $(echo "$images" | sort -u); do
+    digest=$(docker image inspect --format '{{index .RepoDigests 0}}' "$image" 2>/dev/null)
+    if [ -z "$digest" ]; then
+      echo -e "${YELLOW}Warning: $image not found locally, exporting without digest (run prewarm first to pin it)${NC}"
+      digest="$image"
+    fi
+    echo "$image $digest" >> "$export_dir/images.txt"
+  done
+
+  cat > "$export_dir/pull-images.sh" <<'PULL'
+#!/usr/bin/env bash
+# Pull images by pinned digest and tag them with the name used in docker-compose.yaml
+SCRIPT_DIR=$( cd -- "$( dirname -- "${BASH_SOURCE[0]}" )" &> /dev/null && pwd )
+while read -r image digest; do
+  docker pull "$digest" && docker tag "$digest" "$image" || exit 1
+done < "$SCRIPT_DIR/images.txt"
+PULL
+  chmod +x "$export_dir/pull-images.sh"
+
+  echo -e "${GREEN}Exported portable environment to $export_dir. To run it on another machine:${NC}"
+  echo "    cd $export_dir"
+  echo "    ./pull-images.sh"
+  echo "    ./run.sh ${*:-<services>}"
+}
+
 log_how_to_connect() {
   echo -e "${GREEN}How to connect:${NC}"
   connect_result=("${YELLOW}Service,${YELLOW}Container To Container,Host To Container,Container To Host")
@@ -152,14 +372,25 @@ case $1 in
     usage
     ;;
   "-c"|"connect")
-    connect_to_service "$2"
+    connect_to_service "${@:2}"
     ;;
   "-d"|"down")
     shutdown_service "${@:2}"
     ;;
+  "detect")
+    detect_services "$2"
+    ;;
+  "export")
+    check_docker_installed
+    export_portable "${@:2}"
+    ;;
   "-l"|"list")
     list_supported_services
     ;;
+  "-p"|"prewarm")
+    check_docker_installed
+    prewarm_services "${@:2}"
+    ;;
   "-r"|"remove")
     remove_persisted_data "${@:2}"
     ;;